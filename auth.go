@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var validate = validator.New()
+
+// AuthConfig เก็บค่าตั้งค่าของ JWT ที่ใช้เซ็น token ตอน login และรายชื่ออีเมล
+// ที่จะได้ admin claim โดยอัตโนมัติ (ไม่มี endpoint ไหนให้ self-promote ได้)
+type AuthConfig struct {
+	JWTSecret       string   `json:"jwt_secret"`
+	TokenTTLMinutes int      `json:"token_ttl_minutes"`
+	AdminEmails     []string `json:"admin_emails"`
+}
+
+func (a AuthConfig) tokenTTL() time.Duration {
+	return time.Duration(a.TokenTTLMinutes) * time.Minute
+}
+
+// isAdminEmail เช็คว่าอีเมลนี้อยู่ในรายชื่อ admin ที่ตั้งไว้ใน config หรือไม่
+func (a AuthConfig) isAdminEmail(email string) bool {
+	for _, admin := range a.AdminEmails {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// authClaims คือ claim ที่ฝังลง JWT ตอน login สำเร็จ
+type authClaims struct {
+	UserID uint `json:"user_id"`
+	Admin  bool `json:"admin"`
+	jwt.RegisteredClaims
+}
+
+type registerInput struct {
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+type loginInput struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// POST /register
+func registerUser(c *gin.Context) {
+	var input registerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors(err)})
+		return
+	}
+
+	var existing User
+	if err := db.Where("email = ?", input.Email).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "email already registered"})
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not process password"})
+		return
+	}
+
+	user := User{Name: input.Name, Email: input.Email, Password: string(hashed), Admin: cfg.Auth.isAdminEmail(input.Email)}
+	if err := db.Create(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// POST /login
+func loginUser(c *gin.Context) {
+	var input loginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors(err)})
+		return
+	}
+
+	var user User
+	if err := db.Where("email = ?", input.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+
+	// sync admin claim กับ config.json ทุกครั้งที่ login เผื่อ admin_emails เปลี่ยนหลังจาก
+	// user คนนี้ register ไปแล้ว โดยไม่ต้องมี endpoint แยกไว้ promote
+	if isAdmin := cfg.Auth.isAdminEmail(user.Email); isAdmin != user.Admin {
+		user.Admin = isAdmin
+		db.Model(&user).Update("admin", isAdmin)
+	}
+
+	token, err := signToken(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+func signToken(user User) (string, error) {
+	now := time.Now()
+	claims := authClaims{
+		UserID: user.ID,
+		Admin:  user.Admin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.Auth.tokenTTL())),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(cfg.Auth.JWTSecret))
+}
+
+// parseBearerToken ดึงและตรวจ JWT จาก header Authorization: Bearer <token>
+func parseBearerToken(c *gin.Context) (*authClaims, bool) {
+	header := c.GetHeader("Authorization")
+	tokenString := strings.TrimPrefix(header, "Bearer ")
+	if tokenString == "" || tokenString == header {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+		return nil, false
+	}
+
+	claims := &authClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(cfg.Auth.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		return nil, false
+	}
+	return claims, true
+}
+
+// AuthRequired ตรวจ JWT แล้วบังคับว่า :id ใน path ต้องตรงกับเจ้าของ token เอง
+// เว้นแต่ token จะมี admin claim
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := parseBearerToken(c)
+		if !ok {
+			return
+		}
+
+		if !claims.Admin && strconv.FormatUint(uint64(claims.UserID), 10) != c.Param("id") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Next()
+	}
+}
+
+// AdminRequired ตรวจ JWT และบังคับว่า token ต้องมี admin claim เท่านั้น
+// ใช้กับ endpoint ที่เปิดเผยข้อมูลข้าม user เช่น listUsers
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := parseBearerToken(c)
+		if !ok {
+			return
+		}
+		if !claims.Admin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin only"})
+			return
+		}
+		c.Set("userID", claims.UserID)
+		c.Next()
+	}
+}
+
+func validationErrors(err error) []string {
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []string{err.Error()}
+	}
+	out := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		out = append(out, fmt.Sprintf("%s failed on %q", fe.Field(), fe.Tag()))
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"wansanjou/email-api/email"
+)
+
+// Config คือค่าตั้งค่าทั้งหมดของแอป อ่านจาก config.json ที่ไม่ต้อง commit ลง git
+type Config struct {
+	Email    email.Config   `json:"email"`
+	Auth     AuthConfig     `json:"auth"`
+	Telegram TelegramConfig `json:"telegram"`
+}
+
+const configPath = "config.json"
+
+// loadConfig อ่าน config.json ถ้าไม่พบไฟล์จะ fallback เป็น email driver แบบ
+// log-only แทนที่จะ hardcode credential ไว้ในซอร์สโค้ดเหมือนเดิม
+func loadConfig() Config {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		log.Printf("ไม่พบ %s, ใช้ email driver แบบ log-only แทน\n", configPath)
+		return defaultConfig()
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		log.Fatal("config.json ไม่ถูกต้อง:", err)
+	}
+	applyDefaults(&cfg)
+	return cfg
+}
+
+func defaultConfig() Config {
+	cfg := Config{}
+	applyDefaults(&cfg)
+	return cfg
+}
+
+func applyDefaults(cfg *Config) {
+	if cfg.Email.Driver == "" {
+		cfg.Email.Driver = "log"
+	}
+	if cfg.Email.DefaultLang == "" {
+		cfg.Email.DefaultLang = "th"
+	}
+	if cfg.Email.TemplateDir == "" {
+		cfg.Email.TemplateDir = "templates"
+	}
+	if cfg.Email.LocaleDir == "" {
+		cfg.Email.LocaleDir = "locales"
+	}
+	if cfg.Auth.JWTSecret == "" {
+		log.Println("ไม่ได้ตั้ง auth.jwt_secret ใน config.json, ใช้ dev secret ชั่วคราว (ห้ามใช้จริง)")
+		cfg.Auth.JWTSecret = "dev-secret-change-me"
+	}
+	if cfg.Auth.TokenTTLMinutes <= 0 {
+		cfg.Auth.TokenTTLMinutes = 60
+	}
+}
@@ -0,0 +1,35 @@
+package email
+
+import "fmt"
+
+// Config เก็บค่าตั้งค่าอีเมลทั้งหมด อ่านมาจาก config.json ของแอปหลัก
+// เพื่อไม่ให้ credential หลุดมาอยู่ในซอร์สโค้ดเหมือนเดิม
+type Config struct {
+	Driver      string `json:"driver"` // "smtp", "mailgun" หรือ "log"
+	From        string `json:"from"`
+	TemplateDir string `json:"template_dir"`
+	LocaleDir   string `json:"locale_dir"`
+	DefaultLang string `json:"default_lang"`
+
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUser     string `json:"smtp_user"`
+	SMTPPassword string `json:"smtp_password"`
+
+	MailgunDomain string `json:"mailgun_domain"`
+	MailgunAPIKey string `json:"mailgun_api_key"`
+}
+
+// New สร้าง Emailer ตาม driver ที่ตั้งไว้ใน config
+func New(cfg Config) (Emailer, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return &LogEmailer{}, nil
+	case "smtp":
+		return NewSMTPEmailer(cfg), nil
+	case "mailgun":
+		return NewMailgunEmailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown email driver: %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,16 @@
+// Package email ให้ interface กลางสำหรับส่งอีเมล พร้อม driver หลายแบบ
+// (smtp, mailgun, log-only) เลือกได้จาก config โดยไม่ต้อง hardcode credential
+// หรือเนื้อหาอีเมลไว้ในซอร์สโค้ด
+package email
+
+// Message คือเนื้อหาอีเมลที่ประกอบเสร็จแล้ว พร้อมส่งผ่าน Emailer ตัวไหนก็ได้
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Emailer คือ interface กลางที่ driver แต่ละตัวต้อง implement
+type Emailer interface {
+	Send(to string, msg Message) error
+}
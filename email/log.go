@@ -0,0 +1,11 @@
+package email
+
+import "log"
+
+// LogEmailer ไม่ส่งอีเมลจริง แค่ log เนื้อหาไว้ เอาไว้ใช้ตอน dev หรือยังไม่ตั้งค่า driver
+type LogEmailer struct{}
+
+func (e *LogEmailer) Send(to string, msg Message) error {
+	log.Printf("[email:log] to=%s subject=%q\n%s\n", to, msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,52 @@
+package email
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MailgunEmailer ส่งอีเมลผ่าน Mailgun HTTP API แทนการใช้ SDK แยกต่างหาก
+type MailgunEmailer struct {
+	from   string
+	domain string
+	apiKey string
+}
+
+func NewMailgunEmailer(cfg Config) *MailgunEmailer {
+	return &MailgunEmailer{from: cfg.From, domain: cfg.MailgunDomain, apiKey: cfg.MailgunAPIKey}
+}
+
+func (e *MailgunEmailer) Send(to string, msg Message) error {
+	endpoint := fmt.Sprintf("https://api.mailgun.net/v3/%s/messages", e.domain)
+
+	form := url.Values{}
+	form.Set("from", e.from)
+	form.Set("to", to)
+	form.Set("subject", msg.Subject)
+	if msg.Text != "" {
+		form.Set("text", msg.Text)
+	}
+	if msg.HTML != "" {
+		form.Set("html", msg.HTML)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("api", e.apiKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("mailgun: unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package email
+
+import gomail "gopkg.in/gomail.v2"
+
+// SMTPEmailer ส่งอีเมลผ่าน SMTP (เดิมคือ gomail ที่ฝัง Gmail credential ไว้ในซอร์ส)
+type SMTPEmailer struct {
+	from     string
+	host     string
+	port     int
+	user     string
+	password string
+}
+
+func NewSMTPEmailer(cfg Config) *SMTPEmailer {
+	return &SMTPEmailer{
+		from:     cfg.From,
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		user:     cfg.SMTPUser,
+		password: cfg.SMTPPassword,
+	}
+}
+
+func (e *SMTPEmailer) Send(to string, msg Message) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.from)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", msg.Subject)
+
+	if msg.HTML != "" {
+		m.SetBody("text/html", msg.HTML)
+		if msg.Text != "" {
+			m.AddAlternative("text/plain", msg.Text)
+		}
+	} else {
+		m.SetBody("text/plain", msg.Text)
+	}
+
+	d := gomail.NewDialer(e.host, e.port, e.user, e.password)
+	return d.DialAndSend(m)
+}
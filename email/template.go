@@ -0,0 +1,81 @@
+package email
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	texttemplate "text/template"
+)
+
+type expiryData struct {
+	Products []string
+	Strings  map[string]string
+}
+
+// ConstructExpiry ประกอบเนื้อหาอีเมลแจ้งเตือนสินค้าใกล้หมดอายุจาก template และ
+// locale ไฟล์บนดิสก์ แทนที่ข้อความไทยที่เคย hardcode ไว้ในซอร์ส
+func ConstructExpiry(cfg Config, products []string, lang string) (Message, error) {
+	if lang == "" {
+		lang = cfg.DefaultLang
+	}
+	if lang == "" {
+		lang = "en"
+	}
+
+	strs, err := loadLocale(cfg.LocaleDir, lang)
+	if err != nil {
+		return Message{}, err
+	}
+	data := expiryData{Products: products, Strings: strs}
+
+	text, err := renderText(filepath.Join(cfg.TemplateDir, "expiry.txt.tmpl"), data)
+	if err != nil {
+		return Message{}, err
+	}
+	html, err := renderHTML(filepath.Join(cfg.TemplateDir, "expiry.html.tmpl"), data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Subject: strs["expiry_subject"], Text: text, HTML: html}, nil
+}
+
+func loadLocale(dir, lang string) (map[string]string, error) {
+	path := filepath.Join(dir, lang+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading locale %q: %w", lang, err)
+	}
+	var strs map[string]string
+	if err := json.Unmarshal(data, &strs); err != nil {
+		return nil, fmt.Errorf("parsing locale %q: %w", lang, err)
+	}
+	return strs, nil
+}
+
+func renderText(path string, data expiryData) (string, error) {
+	tmpl, err := texttemplate.New(filepath.Base(path)).ParseFiles(path)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderHTML(path string, data expiryData) (string, error) {
+	tmpl, err := htmltemplate.New(filepath.Base(path)).ParseFiles(path)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -8,74 +8,114 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/robfig/cron/v3"
-	gomail "gopkg.in/gomail.v2"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+
+	"wansanjou/email-api/email"
 )
 
 var db *gorm.DB
+var cfg Config
+var mailer email.Emailer
 
 type User struct {
 	ID       uint      `gorm:"primaryKey"`
 	Name     string    `json:"name"`
-	Email    string    `json:"email"`
+	Email    string    `json:"email" gorm:"uniqueIndex"`
+	Password string    `json:"-"`
+	Admin    bool      `json:"-" gorm:"default:false"`
 	Products []Product `json:"products"`
 }
 
 type Product struct {
-	ID        uint      `gorm:"primaryKey"`
-	Name      string    `json:"name"`
-	Expiry    time.Time `json:"expiry"`
-	UserID    uint      `json:"user_id"`
+	ID        uint       `gorm:"primaryKey"`
+	Name      string     `json:"name"`
+	Expiry    time.Time  `json:"expiry"`
+	ValidTill *time.Time `json:"valid_till,omitempty"`
+	Expired   bool       `json:"expired" gorm:"default:false"`
+	UserID    uint       `json:"user_id"`
 	CreatedAt time.Time
 }
 
+// validityInput คือระยะเวลาอายุการใช้งานที่ตั้งได้ตอนสร้าง product
+// หรือตอนต่ออายุ เช่น {"days": 30} หรือ {"days": 1, "hours": 12}
+type validityInput struct {
+	Days    int `json:"days"`
+	Hours   int `json:"hours"`
+	Minutes int `json:"minutes"`
+}
+
+func (v validityInput) duration() time.Duration {
+	return time.Duration(v.Days)*24*time.Hour +
+		time.Duration(v.Hours)*time.Hour +
+		time.Duration(v.Minutes)*time.Minute
+}
+
+func (v validityInput) isZero() bool {
+	return v.Days == 0 && v.Hours == 0 && v.Minutes == 0
+}
+
 func main() {
+	cfg = loadConfig()
+
 	var err error
+	mailer, err = email.New(cfg.Email)
+	if err != nil {
+		log.Fatal("failed to set up email driver:", err)
+	}
+
 	db, err = gorm.Open(sqlite.Open("products.db"), &gorm.Config{})
 	if err != nil {
 		log.Fatal("failed to connect database")
 	}
 
-	// migrate ทั้ง User และ Product
-	db.AutoMigrate(&User{}, &Product{})
+	// migrate ทั้ง User, Product, notification settings และ telegram link
+	db.AutoMigrate(&User{}, &Product{}, &NotificationSetting{}, &SentNotification{}, &TelegramLink{})
 
 	r := gin.Default()
 
-	// User APIs
-	r.POST("/users", createUser)
-	r.GET("/users", listUsers)
-
-	// Product APIs ต่อกับ user
-	r.POST("/users/:id/products", addProductToUser)
-	r.GET("/users/:id/products", listUserProducts)
+	// Auth APIs
+	r.POST("/register", registerUser)
+	r.POST("/login", loginUser)
+
+	// User APIs — listUsers เปิดเผยข้อมูล user ทุกคน (รวม products) จึงจำกัดไว้ที่ admin เท่านั้น
+	r.GET("/users", AdminRequired(), listUsers)
+
+	// /users/:id/... ทั้งหมดต้องแนบ JWT ของเจ้าของ id นั้น (หรือมี admin claim)
+	userRoutes := r.Group("/users/:id")
+	userRoutes.Use(AuthRequired())
+	{
+		// Product APIs ต่อกับ user
+		userRoutes.POST("/products", addProductToUser)
+		userRoutes.GET("/products", listUserProducts)
+		userRoutes.PATCH("/products/:pid/extend", extendProduct)
+		userRoutes.POST("/products/:pid/notify", notifyProduct)
+
+		// Notification settings ต่อ user
+		userRoutes.GET("/notifications", getNotificationSettings)
+		userRoutes.PUT("/notifications", updateNotificationSettings)
+
+		// Telegram
+		userRoutes.POST("/telegram/link", linkTelegram)
+	}
 
-	// ตั้ง cron ให้รันทุกวัน 8 โมงเช้า
 	c := cron.New()
-	_, err = c.AddFunc("@every 1m", checkExpiryJob)
+	if err := scheduleExpiryJobs(c); err != nil {
+		log.Fatal("cron error:", err)
+	}
+	// เช็ค product ที่หมดอายุตาม ValidTill ทุกวัน แล้ว flag ว่า expired
+	_, err = c.AddFunc("@daily", cleanupExpiredProductsJob)
 	if err != nil {
 		log.Fatal("cron error:", err)
 	}
 	c.Start()
 
-	log.Println("Server started on :8081")
-	r.Run(":8081")
-}
-
-// สมัคร user
-func createUser(c *gin.Context) {
-	var input struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	if cfg.Telegram.Enabled {
+		go startTelegramBot(cfg.Telegram)
 	}
 
-	user := User{Name: input.Name, Email: input.Email}
-	db.Create(&user)
-	c.JSON(http.StatusOK, user)
+	log.Println("Server started on :8081")
+	r.Run(":8081")
 }
 
 // list users
@@ -88,8 +128,9 @@ func listUsers(c *gin.Context) {
 // เพิ่ม product ให้ user
 func addProductToUser(c *gin.Context) {
 	var input struct {
-		Name   string    `json:"name"`
-		Expiry time.Time `json:"expiry"`
+		Name     string    `json:"name"`
+		Expiry   time.Time `json:"expiry"`
+		Validity validityInput
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -103,11 +144,74 @@ func addProductToUser(c *gin.Context) {
 	}
 
 	product := Product{Name: input.Name, Expiry: input.Expiry, UserID: user.ID}
+	if !input.Validity.isZero() {
+		validTill := time.Now().Add(input.Validity.duration())
+		product.ValidTill = &validTill
+	}
 	db.Create(&product)
 
 	c.JSON(http.StatusOK, product)
 }
 
+// ต่ออายุ product โดยเลื่อน ValidTill ออกไปจากเดิม (หรือจากตอนนี้ถ้ายังไม่เคยตั้ง)
+func extendProduct(c *gin.Context) {
+	var input validityInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.isZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "must provide days, hours or minutes"})
+		return
+	}
+
+	product, err := findUserProduct(c.Param("id"), c.Param("pid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	base := time.Now()
+	if product.ValidTill != nil && product.ValidTill.After(base) {
+		base = *product.ValidTill
+	}
+	validTill := base.Add(input.duration())
+	product.ValidTill = &validTill
+	product.Expired = false
+	db.Save(&product)
+
+	c.JSON(http.StatusOK, product)
+}
+
+// สั่งส่ง notification ของ product นี้ทันที โดยไม่ต้องรอ cron
+func notifyProduct(c *gin.Context) {
+	var user User
+	if err := db.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	product, err := findUserProduct(c.Param("id"), c.Param("pid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	daysLeft := int(product.Expiry.Sub(time.Now()).Hours() / 24)
+	setting := settingForUser(user.ID)
+	deliverNotification(user, setting, []string{fmt.Sprintf("%s (เหลือ %d วัน)", product.Name, daysLeft)})
+
+	c.JSON(http.StatusOK, gin.H{"status": "notified"})
+}
+
+func findUserProduct(userID, productID string) (Product, error) {
+	var product Product
+	if err := db.Where("id = ? AND user_id = ?", productID, userID).First(&product).Error; err != nil {
+		return Product{}, fmt.Errorf("product not found")
+	}
+	return product, nil
+}
+
 // list products ของ user
 func listUserProducts(c *gin.Context) {
 	var products []Product
@@ -140,64 +244,34 @@ func listProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, products)
 }
 
-func sendEmail(productName string, daysLeft int) {
-	m := gomail.NewMessage()
-	m.SetHeader("From", "your-email@gmail.com")
-	m.SetHeader("To", "receiver@gmail.com")
-	m.SetHeader("Subject", "สินค้าใกล้หมดอายุ")
-	m.SetBody("text/plain", fmt.Sprintf("สินค้า %s จะหมดอายุใน %d วัน", productName, daysLeft))
+// cleanupExpiredProductsJob เช็ค product ที่มี ValidTill เลยกำหนดแล้ว
+// แล้ว flag เป็น expired แทนการลบทิ้งเลย (soft-flag แบบเดียวกับ jfa-go user expiry)
+func cleanupExpiredProductsJob() {
+	log.Println("Running expired products cleanup job...")
 
-	d := gomail.NewDialer("smtp.gmail.com", 587, "your-email@gmail.com", "your-app-password")
-
-	if err := d.DialAndSend(m); err != nil {
-		log.Println("ส่งอีเมลผิดพลาด:", err)
-	} else {
-		log.Println("ส่งอีเมลแล้ว:", productName)
+	result := db.Model(&Product{}).
+		Where("valid_till IS NOT NULL AND valid_till < ? AND expired = ?", time.Now(), false).
+		Update("expired", true)
+	if result.Error != nil {
+		log.Println("cleanup job error:", result.Error)
+		return
 	}
-}
-
-func checkExpiryJob() {
-	log.Println("Running expiry check job...")
-
-	var users []User
-	db.Preload("Products").Find(&users)
-
-	now := time.Now()
-	for _, u := range users {
-		var expiring []string
-		for _, p := range u.Products {
-			daysLeft := int(p.Expiry.Sub(now).Hours() / 24)
-			if daysLeft <= 3 {
-				expiring = append(expiring, fmt.Sprintf("%s (เหลือ %d วัน)", p.Name, daysLeft))
-			}
-		}
-
-		// ถ้ามีสินค้าที่ใกล้หมดอายุ → ส่งอีเมล
-		if len(expiring) > 0 {
-			log.Printf("เตรียมส่งอีเมลไปที่ %s : %v\n", u.Email, expiring)
-			sendEmailToUser(u.Email, expiring)
-		}
+	if result.RowsAffected > 0 {
+		log.Printf("Flagged %d product(s) as expired\n", result.RowsAffected)
 	}
 }
 
-func sendEmailToUser(email string, products []string) {
-	m := gomail.NewMessage()
-	m.SetHeader("From", "your-email@gmail.com") // ต้องเป็น Gmail จริง
-	m.SetHeader("To", email)
-	m.SetHeader("Subject", "แจ้งเตือนสินค้าของคุณใกล้หมดอายุ")
-
-	body := "รายการสินค้าที่ใกล้หมดอายุ:\n"
-	for _, p := range products {
-		body += "- " + p + "\n"
+// sendEmailToUser ประกอบเนื้อหาจาก template/locale ที่ตั้งไว้ใน config แล้วส่งผ่าน
+// Emailer ตัวที่ config เลือกไว้ (smtp/mailgun/log)
+func sendEmailToUser(to string, products []string) {
+	msg, err := email.ConstructExpiry(cfg.Email, products, cfg.Email.DefaultLang)
+	if err != nil {
+		log.Println("สร้างเนื้อหาอีเมลผิดพลาด:", err)
+		return
 	}
-	m.SetBody("text/plain", body)
-
-	// ใช้ App Password ของ Gmail
-	d := gomail.NewDialer("smtp.gmail.com", 587, "watcharapol2c@gmail.com", "ombc hhai loun juam")
-
-	if err := d.DialAndSend(m); err != nil {
-		log.Println("❌ ส่งอีเมลผิดพลาด:", err)
-	} else {
-		log.Println("✅ ส่งอีเมลแล้ว:", email)
+	if err := mailer.Send(to, msg); err != nil {
+		log.Println("ส่งอีเมลผิดพลาด:", err)
+		return
 	}
+	log.Println("ส่งอีเมลแล้ว:", to)
 }
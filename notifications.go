@@ -0,0 +1,286 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+)
+
+// defaultCronSpec คือตารางเวลาที่ใช้กับ user ที่ยังไม่ได้ตั้ง NotificationSetting ของตัวเอง
+const defaultCronSpec = "@every 1m"
+
+// defaultThresholds คือค่าเริ่มต้นตอนยังไม่มีการตั้งค่า (เดิม hardcode ไว้ที่ <= 3 วัน)
+var defaultThresholds = IntCSV{3}
+
+// IntCSV เก็บ threshold หลายค่า (หน่วยเป็นวัน) ลงคอลัมน์เดียวแบบ comma-separated
+// เพราะ sqlite ไม่มี array type ให้ใช้ตรง ๆ
+type IntCSV []int
+
+func (i IntCSV) Value() (driver.Value, error) {
+	parts := make([]string, len(i))
+	for idx, v := range i {
+		parts[idx] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+func (i *IntCSV) Scan(value interface{}) error {
+	if value == nil {
+		*i = nil
+		return nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		b, ok := value.([]byte)
+		if !ok {
+			return fmt.Errorf("unsupported type for IntCSV: %T", value)
+		}
+		s = string(b)
+	}
+	if s == "" {
+		*i = nil
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make(IntCSV, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return fmt.Errorf("invalid threshold value %q: %w", p, err)
+		}
+		out = append(out, n)
+	}
+	*i = out
+	return nil
+}
+
+// deliveryMethod ควบคุมว่าจะยิงแจ้งเตือนไปช่องทางไหน: "email", "telegram" หรือ "both"
+const (
+	deliveryEmail    = "email"
+	deliveryTelegram = "telegram"
+	deliveryBoth     = "both"
+)
+
+// NotificationSetting คือ preference การแจ้งเตือนของ user แต่ละคน
+// อิงแนวคิด "notify" map ของ jfa-go
+type NotificationSetting struct {
+	ID             uint       `gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"uniqueIndex"`
+	Thresholds     IntCSV     `json:"thresholds"`
+	CronSpec       string     `json:"cron_spec"`
+	NotifyExpiry   bool       `json:"notify_expiry"`
+	NotifyEmail    bool       `json:"notify_email"`
+	DeliveryMethod string     `json:"delivery_method"` // "email", "telegram" หรือ "both"
+	LastCheckedAt  *time.Time `json:"-"`
+}
+
+// SentNotification กันไม่ให้ threshold เดียวกันของ product เดียวกันถูกส่งซ้ำ
+type SentNotification struct {
+	ID        uint      `gorm:"primaryKey"`
+	ProductID uint      `json:"product_id" gorm:"uniqueIndex:idx_sent_product_threshold"`
+	Threshold int       `json:"threshold" gorm:"uniqueIndex:idx_sent_product_threshold"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+func settingForUser(userID uint) NotificationSetting {
+	var setting NotificationSetting
+	if err := db.Where("user_id = ?", userID).First(&setting).Error; err != nil {
+		return NotificationSetting{
+			UserID:         userID,
+			Thresholds:     defaultThresholds,
+			CronSpec:       defaultCronSpec,
+			NotifyExpiry:   true,
+			NotifyEmail:    true,
+			DeliveryMethod: deliveryEmail,
+		}
+	}
+	if setting.CronSpec == "" {
+		setting.CronSpec = defaultCronSpec
+	}
+	if len(setting.Thresholds) == 0 {
+		setting.Thresholds = defaultThresholds
+	}
+	if setting.DeliveryMethod == "" {
+		setting.DeliveryMethod = deliveryEmail
+	}
+	return setting
+}
+
+// GET /users/:id/notifications
+func getNotificationSettings(c *gin.Context) {
+	var user User
+	if err := db.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	c.JSON(http.StatusOK, settingForUser(user.ID))
+}
+
+// PUT /users/:id/notifications
+func updateNotificationSettings(c *gin.Context) {
+	var user User
+	if err := db.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	var input struct {
+		Thresholds     IntCSV `json:"thresholds"`
+		CronSpec       string `json:"cron_spec"`
+		NotifyExpiry   bool   `json:"notify_expiry"`
+		NotifyEmail    bool   `json:"notify_email"`
+		DeliveryMethod string `json:"delivery_method"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.CronSpec == "" {
+		input.CronSpec = defaultCronSpec
+	}
+	if len(input.Thresholds) == 0 {
+		input.Thresholds = defaultThresholds
+	}
+	switch input.DeliveryMethod {
+	case deliveryEmail, deliveryTelegram, deliveryBoth:
+		// ok
+	case "":
+		input.DeliveryMethod = deliveryEmail
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "delivery_method must be email, telegram or both"})
+		return
+	}
+
+	var setting NotificationSetting
+	db.Where("user_id = ?", user.ID).FirstOrInit(&setting)
+	setting.UserID = user.ID
+	setting.Thresholds = input.Thresholds
+	setting.CronSpec = input.CronSpec
+	setting.NotifyExpiry = input.NotifyExpiry
+	setting.NotifyEmail = input.NotifyEmail
+	setting.DeliveryMethod = input.DeliveryMethod
+	db.Save(&setting)
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// scheduleExpiryJobs ตั้ง tick เดียวที่ fixed interval แล้วให้ checkExpiryJob เป็น
+// คนไล่เช็คทีละ user ว่าถึงรอบ CronSpec ของตัวเองหรือยัง แทนการ AddFunc แยกตาม
+// CronSpec ที่มีอยู่ตอน boot — ของเดิมทำให้ user ที่เพิ่งตั้ง CronSpec ใหม่ผ่าน
+// PUT /users/:id/notifications ไม่มี cron entry รองรับจนกว่าจะ restart process
+func scheduleExpiryJobs(c *cron.Cron) error {
+	if _, err := c.AddFunc(defaultCronSpec, checkExpiryJob); err != nil {
+		return fmt.Errorf("scheduling %q: %w", defaultCronSpec, err)
+	}
+	return nil
+}
+
+// isDue คำนวณจาก CronSpec ของ setting เองว่าถึงรอบที่ต้องเช็คหรือยัง เทียบกับ
+// LastCheckedAt ครั้งล่าสุด (หรือ 1 tick ที่แล้วถ้ายังไม่เคยเช็ค)
+func isDue(setting NotificationSetting, now time.Time) bool {
+	schedule, err := cron.ParseStandard(setting.CronSpec)
+	if err != nil {
+		schedule, _ = cron.ParseStandard(defaultCronSpec)
+	}
+
+	last := now.Add(-time.Minute)
+	if setting.LastCheckedAt != nil {
+		last = *setting.LastCheckedAt
+	}
+	return !schedule.Next(last).After(now)
+}
+
+// checkExpiryJob รันทุก tick คงที่ (defaultCronSpec) แล้วไล่ทีละ user ว่าถึงรอบของ
+// CronSpec ตัวเองหรือยัง ก่อนยิงอีเมล/telegram แบบขนานด้วย WaitGroup
+func checkExpiryJob() {
+	log.Println("Running expiry check tick...")
+
+	now := time.Now()
+	var users []User
+	db.Preload("Products").Find(&users)
+
+	var wg sync.WaitGroup
+	for _, u := range users {
+		setting := settingForUser(u.ID)
+		if !setting.NotifyExpiry || !isDue(setting, now) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(u User, setting NotificationSetting) {
+			defer wg.Done()
+			notifyUserThresholds(u, setting, now)
+			markChecked(setting.UserID, now)
+		}(u, setting)
+	}
+	wg.Wait()
+}
+
+func markChecked(userID uint, at time.Time) {
+	db.Model(&NotificationSetting{}).Where("user_id = ?", userID).Update("last_checked_at", at)
+}
+
+// notifyUserThresholds ส่งแจ้งเตือนให้ user คนเดียว
+func notifyUserThresholds(u User, setting NotificationSetting, now time.Time) {
+	deliverNotification(u, setting, collectExpiring(u, setting, now))
+}
+
+// collectExpiring ไล่ทุก product และทุก threshold ที่ยัง cross ไม่เคยส่ง บันทึก
+// SentNotification ทีละ threshold ที่ cross (กัน (product, threshold) ซ้ำแบบ
+// atomic ผ่าน unique constraint) แต่ใส่ลง email แค่บรรทัดเดียวต่อ product แม้จะ
+// cross หลาย threshold พร้อมกันในรอบเดียว (เช่น product สร้างมาเหลืออายุ 2 วัน
+// ตรงกับทั้ง threshold 7 และ 3)
+func collectExpiring(u User, setting NotificationSetting, now time.Time) []string {
+	var expiring []string
+
+	for _, p := range u.Products {
+		if p.Expired {
+			continue
+		}
+		daysLeft := int(p.Expiry.Sub(now).Hours() / 24)
+
+		productLineAdded := false
+		for _, threshold := range setting.Thresholds {
+			if daysLeft > threshold {
+				continue
+			}
+			// Insert ก่อนแล้วค่อยเช็ค error แทนการเช็ค-แล้ว-insert เพื่อให้ unique
+			// constraint บน (product_id, threshold) เป็นคนตัดสินแบบ atomic — กัน
+			// กรณี checkExpiryJob สอง run ทับซ้อนกันแล้วส่งอีเมลซ้ำ
+			sent := SentNotification{ProductID: p.ID, Threshold: threshold, SentAt: now}
+			if err := db.Create(&sent).Error; err != nil {
+				continue
+			}
+			if !productLineAdded {
+				expiring = append(expiring, fmt.Sprintf("%s (เหลือ %d วัน)", p.Name, daysLeft))
+				productLineAdded = true
+			}
+		}
+	}
+
+	return expiring
+}
+
+// deliverNotification ส่ง expiring ไปยังช่องทางที่ setting กำหนดไว้ (ใช้ร่วมกันทั้ง
+// checkExpiryJob และปุ่ม "notify now" ของ notifyProduct)
+func deliverNotification(u User, setting NotificationSetting, expiring []string) {
+	if len(expiring) == 0 {
+		return
+	}
+
+	if setting.NotifyEmail && setting.DeliveryMethod != deliveryTelegram {
+		log.Printf("เตรียมส่งอีเมลไปที่ %s : %v\n", u.Email, expiring)
+		sendEmailToUser(u.Email, expiring)
+	}
+	if setting.DeliveryMethod == deliveryTelegram || setting.DeliveryMethod == deliveryBoth {
+		sendTelegramToUser(u.ID, expiring)
+	}
+}
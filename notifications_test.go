@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupNotificationsTestDB(t *testing.T) {
+	t.Helper()
+
+	testDB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := testDB.AutoMigrate(&User{}, &Product{}, &NotificationSetting{}, &SentNotification{}, &TelegramLink{}); err != nil {
+		t.Fatalf("failed to migrate test db: %v", err)
+	}
+	db = testDB
+}
+
+func createTestUserWithProduct(t *testing.T, validFor time.Duration) (User, Product) {
+	t.Helper()
+
+	user := User{Name: "Test", Email: "test@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	product := Product{Name: "Widget", UserID: user.ID, Expiry: time.Now().Add(validFor)}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("failed to create test product: %v", err)
+	}
+	user.Products = []Product{product}
+
+	return user, product
+}
+
+// TestCollectExpiring_OneLinePerProductAcrossCrossedThresholds ยืนยันว่า product
+// ที่ cross มากกว่าหนึ่ง threshold ในรอบเดียวกัน (เช่นเหลืออายุ 2 วัน ตรงกับทั้ง
+// threshold 7 และ 3) ได้ขึ้นบรรทัดในอีเมลแค่ครั้งเดียว ไม่ซ้ำ
+func TestCollectExpiring_OneLinePerProductAcrossCrossedThresholds(t *testing.T) {
+	setupNotificationsTestDB(t)
+	user, product := createTestUserWithProduct(t, 48*time.Hour)
+
+	setting := NotificationSetting{
+		UserID:         user.ID,
+		Thresholds:     IntCSV{7, 3, 1, 0},
+		NotifyExpiry:   true,
+		NotifyEmail:    true,
+		DeliveryMethod: deliveryEmail,
+	}
+
+	now := time.Now()
+	expiring := collectExpiring(user, setting, now)
+
+	if len(expiring) != 1 {
+		t.Fatalf("expected exactly one line for the product, got %d: %v", len(expiring), expiring)
+	}
+
+	var sentCount int64
+	db.Model(&SentNotification{}).Where("product_id = ?", product.ID).Count(&sentCount)
+	if sentCount != 2 {
+		t.Fatalf("expected both crossed thresholds (7 and 3) recorded as sent, got %d", sentCount)
+	}
+}
+
+// TestCollectExpiring_DoesNotResendAlreadySentThreshold จำลองสอง run ของ
+// checkExpiryJob ที่ทับซ้อนกัน (เช่นรอบก่อนหน้ายังไม่ทันจบ) แล้วยืนยันว่า run
+// ที่สองไม่ส่งซ้ำสำหรับ threshold ที่ถูกบันทึกไปแล้ว
+func TestCollectExpiring_DoesNotResendAlreadySentThreshold(t *testing.T) {
+	setupNotificationsTestDB(t)
+	user, _ := createTestUserWithProduct(t, 48*time.Hour)
+
+	setting := NotificationSetting{
+		UserID:         user.ID,
+		Thresholds:     IntCSV{3},
+		NotifyExpiry:   true,
+		NotifyEmail:    true,
+		DeliveryMethod: deliveryEmail,
+	}
+
+	now := time.Now()
+	first := collectExpiring(user, setting, now)
+	if len(first) != 1 {
+		t.Fatalf("expected first run to notify once, got %d: %v", len(first), first)
+	}
+
+	second := collectExpiring(user, setting, now)
+	if len(second) != 0 {
+		t.Fatalf("expected overlapping second run to send nothing, got %v", second)
+	}
+}
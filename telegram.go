@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/gin-gonic/gin"
+)
+
+// TelegramConfig เปิด/ปิด Telegram เป็นอีกช่องทางแจ้งเตือน นอกจากอีเมล
+type TelegramConfig struct {
+	Enabled bool   `json:"enabled"`
+	Token   string `json:"token"`
+}
+
+// telegramBot คือ instance เดียวที่ใช้ทั้งรับ update และส่งข้อความแจ้งเตือน
+var telegramBot *tgbotapi.BotAPI
+
+// pinTTL คืออายุของ PIN ที่ออกให้ตอน /telegram/link ก่อนที่จะใช้ไม่ได้อีก
+const pinTTL = 10 * time.Minute
+
+// TelegramLink ผูก user กับ chat ID ของ Telegram หลังยืนยันด้วย PIN แบบใช้ครั้งเดียว
+type TelegramLink struct {
+	ID           uint       `gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"uniqueIndex"`
+	ChatID       int64      `json:"chat_id"`
+	PIN          string     `json:"-"`
+	PINExpiresAt *time.Time `json:"-"`
+	Linked       bool       `json:"linked"`
+	CreatedAt    time.Time
+}
+
+// POST /users/:id/telegram/link ออก PIN ใหม่ให้ user เอาไปส่งให้บอทด้วย /start <PIN>
+func linkTelegram(c *gin.Context) {
+	var user User
+	if err := db.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	pin, err := generateUniquePIN()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expiresAt := time.Now().Add(pinTTL)
+
+	var link TelegramLink
+	db.Where("user_id = ?", user.ID).FirstOrInit(&link)
+	link.UserID = user.ID
+	link.PIN = pin
+	link.PINExpiresAt = &expiresAt
+	link.Linked = false
+	db.Save(&link)
+
+	c.JSON(http.StatusOK, gin.H{
+		"pin":          pin,
+		"expires_at":   expiresAt,
+		"instructions": fmt.Sprintf("ส่งข้อความ /start %s ให้บอทเพื่อเชื่อมต่อบัญชีภายใน %s", pin, pinTTL),
+	})
+}
+
+// generateUniquePIN สุ่ม PIN 6 หลักที่ไม่ชนกับ PIN ของ link อื่นที่ยังไม่หมดอายุ
+// เพื่อกันไม่ให้ผู้ใช้สองคนที่ขอ PIN ใกล้กันไปผูกบัญชีผิดคน
+func generateUniquePIN() (string, error) {
+	for attempt := 0; attempt < 10; attempt++ {
+		pin := fmt.Sprintf("%06d", rand.Intn(1000000))
+
+		var count int64
+		db.Model(&TelegramLink{}).
+			Where("pin = ? AND linked = ? AND pin_expires_at > ?", pin, false, time.Now()).
+			Count(&count)
+		if count == 0 {
+			return pin, nil
+		}
+	}
+	return "", fmt.Errorf("could not generate a unique pin, please try again")
+}
+
+// startTelegramBot รัน long-polling loop รับ /start <PIN> จากผู้ใช้ แล้วผูก chat ID
+// เข้ากับ TelegramLink ที่รอ PIN นั้นอยู่
+func startTelegramBot(cfg TelegramConfig) {
+	bot, err := tgbotapi.NewBotAPI(cfg.Token)
+	if err != nil {
+		log.Println("telegram bot error:", err)
+		return
+	}
+	telegramBot = bot
+	log.Printf("Telegram bot authorized as %s\n", bot.Self.UserName)
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	updates := bot.GetUpdatesChan(u)
+
+	for update := range updates {
+		if update.Message == nil {
+			continue
+		}
+		handleTelegramMessage(bot, update.Message)
+	}
+}
+
+func handleTelegramMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	fields := strings.Fields(msg.Text)
+	if len(fields) != 2 || fields[0] != "/start" {
+		return
+	}
+	pin := fields[1]
+
+	var link TelegramLink
+	if err := db.Where("pin = ? AND linked = ? AND pin_expires_at > ?", pin, false, time.Now()).First(&link).Error; err != nil {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "PIN ไม่ถูกต้อง ถูกใช้ไปแล้ว หรือหมดอายุแล้ว"))
+		return
+	}
+
+	link.ChatID = msg.Chat.ID
+	link.Linked = true
+	link.PIN = ""
+	link.PINExpiresAt = nil
+	db.Save(&link)
+
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "เชื่อมต่อบัญชีสำเร็จ!"))
+}
+
+// sendTelegramToUser ส่งรายการสินค้าใกล้หมดอายุไปที่ chat ที่ user ผูกไว้ ถ้ายังไม่ได้
+// ผูกบัญชีหรือบอทยังไม่ได้ตั้งค่าไว้ก็แค่ข้ามไป
+func sendTelegramToUser(userID uint, products []string) {
+	if telegramBot == nil {
+		return
+	}
+
+	var link TelegramLink
+	if err := db.Where("user_id = ? AND linked = ?", userID, true).First(&link).Error; err != nil {
+		return
+	}
+
+	body := "รายการสินค้าที่ใกล้หมดอายุ:\n"
+	for _, p := range products {
+		body += "- " + p + "\n"
+	}
+
+	if _, err := telegramBot.Send(tgbotapi.NewMessage(link.ChatID, body)); err != nil {
+		log.Println("telegram send error:", err)
+	}
+}